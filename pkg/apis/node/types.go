@@ -0,0 +1,126 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package node is the internal version of the API.
+package node
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/apis/core"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// RuntimeClass defines a class of container runtime supported in the cluster.
+// The RuntimeClass is used to determine which container runtime is used to
+// run all containers in a pod. RuntimeClasses are (currently) manually defined
+// by a user or cluster provisioner, and referenced in the PodSpec. The
+// Kubelet is responsible for resolving the RuntimeClassName reference before
+// running the pod.  For more details, see
+// https://git.k8s.io/enhancements/keps/sig-node/585-runtime-class
+type RuntimeClass struct {
+	metav1.TypeMeta
+	// metav1.ObjectMeta.Name is the name of the RuntimeClass, and is used to
+	// refer to this RuntimeClass from a Pod via the RuntimeClassName field.
+	metav1.ObjectMeta
+
+	// Handler specifies the underlying runtime and configuration that the CRI
+	// implementation will use to handle pods of this class. The possible
+	// values are specific to the node & CRI configuration. It is assumed that
+	// all handlers are available on every node, and handlers of the same name
+	// are equivalent on every node. For example, a handler called "runc"
+	// might specify that the runc OCI runtime (using native Linux containers)
+	// will be used to run the containers in a pod. The Handler must conform
+	// to the DNS Label (RFC 1123) requirements, and is immutable.
+	Handler string
+
+	// Topology describes the node-level scheduling constraints that apply to
+	// pods using this RuntimeClass.
+	// +optional
+	Topology *Topology
+
+	// Overhead represents the resource overhead associated with running a
+	// pod under this RuntimeClass, for example the additional memory and CPU
+	// a VM-based sandbox consumes on top of the pod's own requests. The
+	// resources specified here are added to the pod's effective resource
+	// requests when the pod is admitted, so the scheduler and kubelet account
+	// for them. This field is immutable once the RuntimeClass is created.
+	// +optional
+	Overhead *Overhead
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// RuntimeClassList is a list of RuntimeClass objects.
+type RuntimeClassList struct {
+	metav1.TypeMeta
+	// +optional
+	metav1.ListMeta
+
+	// Items is a list of schema objects.
+	Items []RuntimeClass
+}
+
+// Topology holds the node-level scheduling constraints that apply to pods
+// using a RuntimeClass.
+type Topology struct {
+	// NodeSelector lists labels that must be present on nodes that support
+	// this RuntimeClass. Pods using this RuntimeClass can only be scheduled
+	// to a node matched by this selector. The RuntimeClass nodeSelector is
+	// merged with a pod's existing nodeSelector. Any conflicts will cause the
+	// pod to be rejected in admission. The accumulated set of nodeSelector
+	// requirements is immutable once the RuntimeClass is created.
+	// +optional
+	NodeSelector *core.NodeSelector
+
+	// Tolerations are appended (excluding duplicates) to pods running with
+	// this RuntimeClass during admission, effectively unioning the set of
+	// nodes tolerated by the pod and the RuntimeClass.
+	// +optional
+	Tolerations []core.Toleration
+
+	// NamespaceSelector constrains which nodes pods in a matching namespace
+	// may run on when using this RuntimeClass, by selecting a pool of nodes
+	// via their labels. It is merged into the pod's nodeSelector in the same
+	// way as NodeSelector. This allows an administrator to scope a
+	// RuntimeClass to a pool of nodes at the namespace level rather than
+	// requiring every pod to carry the constraint. Immutable.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector
+
+	// DefaultNodeSelector is a label selector string (in the same syntax
+	// accepted by metav1.ParseToLabelSelector) that is applied when a pod
+	// using this RuntimeClass has no NodeSelector of its own. Unlike
+	// NodeSelector, this field may be updated after the RuntimeClass is
+	// created.
+	// +optional
+	DefaultNodeSelector string
+
+	// Requirements is a flattened, single-list alternative to
+	// NodeSelector.NodeSelectorTerms: every requirement in the list must be
+	// satisfied by a node for a pod using this RuntimeClass to be scheduled
+	// there. It is mutually exclusive with NodeSelector, so that the
+	// effective scheduling constraint is always expressed one way. Immutable.
+	// +optional
+	Requirements []core.NodeSelectorRequirement
+}
+
+// Overhead represents the resource overhead associated with running a pod.
+type Overhead struct {
+	// PodFixed represents the fixed resource overhead associated with
+	// running a pod.
+	PodFixed core.ResourceList
+}
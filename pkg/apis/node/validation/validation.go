@@ -0,0 +1,257 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package validation contains methods to validate kinds in the node API group.
+package validation
+
+import (
+	"fmt"
+
+	unversionedvalidation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/kubernetes/pkg/apis/core"
+	corehelper "k8s.io/kubernetes/pkg/apis/core/helper"
+	apivalidation "k8s.io/kubernetes/pkg/apis/core/validation"
+	"k8s.io/kubernetes/pkg/apis/node"
+)
+
+// maxTopologyTolerations bounds the number of Tolerations a RuntimeClass may
+// carry. Tolerations are merged into every pod scheduled with this
+// RuntimeClass, so an unbounded list would let a single RuntimeClass degrade
+// scheduling performance cluster-wide.
+const maxTopologyTolerations = 32
+
+// ValidateRuntimeClass validates the RuntimeClass, prior to persistence.
+func ValidateRuntimeClass(rc *node.RuntimeClass) field.ErrorList {
+	allErrs := apivalidation.ValidateObjectMeta(&rc.ObjectMeta, false, apivalidation.NameIsDNSLabel, field.NewPath("metadata"))
+	for _, msg := range apivalidation.NameIsDNSLabel(rc.Handler, false) {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("handler"), rc.Handler, msg))
+	}
+	allErrs = append(allErrs, validateOverhead(rc.Overhead, field.NewPath("overhead"))...)
+	allErrs = append(allErrs, validateTopology(rc.Topology, field.NewPath("topology"))...)
+	return allErrs
+}
+
+// ValidateRuntimeClassUpdate validates an update to a RuntimeClass.
+func ValidateRuntimeClassUpdate(new, old *node.RuntimeClass) field.ErrorList {
+	allErrs := apivalidation.ValidateObjectMetaUpdate(&new.ObjectMeta, &old.ObjectMeta, field.NewPath("metadata"))
+	allErrs = append(allErrs, apivalidation.ValidateImmutableField(new.Handler, old.Handler, field.NewPath("handler"))...)
+	allErrs = append(allErrs, apivalidation.ValidateImmutableField(new.Overhead, old.Overhead, field.NewPath("overhead"))...)
+	allErrs = append(allErrs, validateTopologyUpdate(new.Topology, old.Topology, field.NewPath("topology"))...)
+	return allErrs
+}
+
+// validateOverheadResourceName reports whether name may be specified in a
+// RuntimeClass's Overhead.PodFixed. Pod-level overhead only makes sense for
+// resources the scheduler and kubelet already account for at the pod level.
+func validateOverheadResourceName(name core.ResourceName) bool {
+	switch name {
+	case core.ResourceCPU, core.ResourceMemory:
+		return true
+	default:
+		return corehelper.IsHugePageResourceName(name)
+	}
+}
+
+func validateOverhead(overhead *node.Overhead, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if overhead == nil {
+		return allErrs
+	}
+	podFixedPath := fldPath.Child("podFixed")
+	for name, quantity := range overhead.PodFixed {
+		resPath := podFixedPath.Key(string(name))
+		if !validateOverheadResourceName(name) {
+			allErrs = append(allErrs, field.NotSupported(resPath, name, []string{string(core.ResourceCPU), string(core.ResourceMemory), "hugepages-*"}))
+			continue
+		}
+		if quantity.Sign() < 0 {
+			allErrs = append(allErrs, field.Invalid(resPath, quantity.String(), "must be a non-negative quantity"))
+		}
+	}
+	return allErrs
+}
+
+func validateTopology(topology *node.Topology, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if topology == nil {
+		return allErrs
+	}
+	if topology.NodeSelector != nil {
+		allErrs = append(allErrs, apivalidation.ValidateNodeSelector(topology.NodeSelector, fldPath.Child("nodeSelector"))...)
+	}
+	if len(topology.Tolerations) > 0 {
+		tolerationsPath := fldPath.Child("tolerations")
+		allErrs = append(allErrs, apivalidation.ValidateTolerations(topology.Tolerations, tolerationsPath)...)
+		allErrs = append(allErrs, validateTolerationsForMerge(topology.Tolerations, tolerationsPath)...)
+	}
+	if topology.NamespaceSelector != nil {
+		allErrs = append(allErrs, unversionedvalidation.ValidateLabelSelector(topology.NamespaceSelector, fldPath.Child("namespaceSelector"))...)
+	}
+	var defaultSelector labels.Selector
+	if len(topology.DefaultNodeSelector) > 0 {
+		selector, err := labels.Parse(topology.DefaultNodeSelector)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("defaultNodeSelector"), topology.DefaultNodeSelector, err.Error()))
+		} else {
+			defaultSelector = selector
+		}
+	}
+	if defaultSelector != nil && topology.NodeSelector != nil {
+		allErrs = append(allErrs, validateNoConflictingKeys(nodeSelectorKeys(topology.NodeSelector), defaultSelector, fldPath.Child("nodeSelector"))...)
+	}
+	if len(topology.Requirements) > 0 {
+		requirementsPath := fldPath.Child("requirements")
+		if topology.NodeSelector != nil {
+			allErrs = append(allErrs, field.Invalid(requirementsPath, topology.Requirements, "may not be specified when `nodeSelector` is set"))
+		}
+		allErrs = append(allErrs, validateRequirements(topology.Requirements, requirementsPath)...)
+		if defaultSelector != nil {
+			allErrs = append(allErrs, validateNoConflictingKeys(requirementKeys(topology.Requirements), defaultSelector, requirementsPath)...)
+		}
+	}
+	return allErrs
+}
+
+// validateRequirements validates a flattened list of node selector
+// requirements, checking each requirement's key/operator/values and
+// rejecting requirements on the same key that can never both be satisfied.
+func validateRequirements(requirements []core.NodeSelectorRequirement, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	// inValues holds the intersection of every In requirement seen so far for
+	// a key: since requirements are ANDed, a node must satisfy all of them at
+	// once, so two In requirements on the same key narrow the permitted set
+	// rather than widening it.
+	inValues := map[string]sets.String{}
+	inCount := map[string]int{}
+	notInValues := map[string]sets.String{}
+	for i, requirement := range requirements {
+		reqPath := fldPath.Index(i)
+		allErrs = append(allErrs, apivalidation.ValidateNodeSelectorRequirement(requirement, reqPath)...)
+		switch requirement.Operator {
+		case core.NodeSelectorOpIn:
+			values := sets.NewString(requirement.Values...)
+			if inCount[requirement.Key] == 0 {
+				inValues[requirement.Key] = values
+			} else {
+				inValues[requirement.Key] = inValues[requirement.Key].Intersection(values)
+			}
+			inCount[requirement.Key]++
+		case core.NodeSelectorOpNotIn:
+			if notInValues[requirement.Key] == nil {
+				notInValues[requirement.Key] = sets.NewString()
+			}
+			notInValues[requirement.Key].Insert(requirement.Values...)
+		}
+	}
+	for key, included := range inValues {
+		// Two or more In requirements on the same key whose allowed values
+		// don't overlap can never be satisfied by any single node.
+		if inCount[key] > 1 && included.Len() == 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath, key, "In requirements on the same key must not be mutually exclusive"))
+			continue
+		}
+		// Requirements are ANDed together, so In{a,b} + NotIn{a} is satisfiable
+		// (by a node labeled "b") even though the sets overlap. Only a NotIn
+		// that covers every value the In permits leaves nothing a node could
+		// match.
+		if excluded, ok := notInValues[key]; ok && included.Len() > 0 && included.Difference(excluded).Len() == 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath, key, "In and NotIn requirements on the same key must not contradict each other"))
+		}
+	}
+	return allErrs
+}
+
+// validateTolerationsForMerge enforces the invariants needed for Tolerations
+// to be safely merged into pod specs at admission. apivalidation.ValidateTolerations
+// already rejects a Value on an `Exists` toleration and a TolerationSeconds
+// not paired with `NoExecute`; this adds the checks that are specific to
+// merging a RuntimeClass's tolerations into every pod that uses it: no
+// duplicate entries, and a cap on the total number of tolerations.
+func validateTolerationsForMerge(tolerations []core.Toleration, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if len(tolerations) > maxTopologyTolerations {
+		allErrs = append(allErrs, field.TooMany(fldPath, len(tolerations), maxTopologyTolerations))
+	}
+	seen := sets.NewString()
+	for i, toleration := range tolerations {
+		key := fmt.Sprintf("%s\x00%s\x00%s\x00%s", toleration.Key, toleration.Operator, toleration.Value, toleration.Effect)
+		if seen.Has(key) {
+			allErrs = append(allErrs, field.Duplicate(fldPath.Index(i), toleration))
+		}
+		seen.Insert(key)
+	}
+	return allErrs
+}
+
+// nodeSelectorKeys returns the label keys constrained by a NodeSelector.
+func nodeSelectorKeys(nodeSelector *core.NodeSelector) []string {
+	var keys []string
+	for _, term := range nodeSelector.NodeSelectorTerms {
+		for _, expr := range term.MatchExpressions {
+			keys = append(keys, expr.Key)
+		}
+	}
+	return keys
+}
+
+// requirementKeys returns the label keys constrained by a flattened list of
+// node selector requirements.
+func requirementKeys(requirements []core.NodeSelectorRequirement) []string {
+	keys := make([]string, 0, len(requirements))
+	for _, requirement := range requirements {
+		keys = append(keys, requirement.Key)
+	}
+	return keys
+}
+
+// validateNoConflictingKeys rejects a per-pod constraint (NodeSelector or
+// Requirements) that constrains a label key the DefaultNodeSelector already
+// constrains, since merging the two would make the effective constraint on
+// that key ambiguous.
+func validateNoConflictingKeys(keys []string, defaultSelector labels.Selector, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	requirements, _ := defaultSelector.Requirements()
+	defaultKeys := sets.NewString()
+	for _, requirement := range requirements {
+		defaultKeys.Insert(requirement.Key())
+	}
+	for _, key := range keys {
+		if defaultKeys.Has(key) {
+			allErrs = append(allErrs, field.Invalid(fldPath, key, "must not conflict with a key in defaultNodeSelector"))
+		}
+	}
+	return allErrs
+}
+
+// validateTopologyUpdate enforces that Topology is immutable once a
+// RuntimeClass exists, with the exception of DefaultNodeSelector, which may
+// be changed (including introduced for the first time on a RuntimeClass
+// that had no Topology at creation) to retarget pods that don't specify
+// their own node selector.
+func validateTopologyUpdate(newTopology, oldTopology *node.Topology, fldPath *field.Path) field.ErrorList {
+	normalize := func(topology *node.Topology) *node.Topology {
+		if topology == nil {
+			return &node.Topology{}
+		}
+		normalized := *topology
+		normalized.DefaultNodeSelector = ""
+		return &normalized
+	}
+	return apivalidation.ValidateImmutableField(normalize(newTopology), normalize(oldTopology), fldPath)
+}
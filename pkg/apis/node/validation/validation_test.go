@@ -17,11 +17,14 @@ limitations under the License.
 package validation
 
 import (
+	"fmt"
 	"testing"
 
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/kubernetes/pkg/apis/core"
 	"k8s.io/kubernetes/pkg/apis/node"
+	"k8s.io/utils/pointer"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -58,6 +61,44 @@ func TestValidateRuntimeClass(t *testing.T) {
 			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
 			Handler:    "bar-baz",
 		},
+	}, {
+		name:        "valid overhead",
+		expectError: false,
+		rc: node.RuntimeClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			Handler:    "bar-baz",
+			Overhead: &node.Overhead{
+				PodFixed: core.ResourceList{
+					core.ResourceCPU:                   resource.MustParse("100m"),
+					core.ResourceMemory:                resource.MustParse("10Mi"),
+					core.ResourceName("hugepages-2Mi"): resource.MustParse("1Gi"),
+				},
+			},
+		},
+	}, {
+		name:        "negative overhead quantity",
+		expectError: true,
+		rc: node.RuntimeClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			Handler:    "bar-baz",
+			Overhead: &node.Overhead{
+				PodFixed: core.ResourceList{
+					core.ResourceCPU: resource.MustParse("-100m"),
+				},
+			},
+		},
+	}, {
+		name:        "unknown overhead resource",
+		expectError: true,
+		rc: node.RuntimeClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			Handler:    "bar-baz",
+			Overhead: &node.Overhead{
+				PodFixed: core.ResourceList{
+					core.ResourceName("example.com/custom"): resource.MustParse("1"),
+				},
+			},
+		},
 	}}
 
 	for _, test := range tests {
@@ -110,6 +151,90 @@ func TestValidateRuntimeUpdate(t *testing.T) {
 			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
 			Handler:    "somethingelse",
 		},
+	}, {
+		name:        "invalid Overhead update",
+		expectError: true,
+		old:         old,
+		new: node.RuntimeClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			Handler:    "bar",
+			Overhead: &node.Overhead{
+				PodFixed: core.ResourceList{
+					core.ResourceCPU: resource.MustParse("100m"),
+				},
+			},
+		},
+	}, {
+		name: "valid DefaultNodeSelector update",
+		old: node.RuntimeClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			Handler:    "bar",
+			Topology: &node.Topology{
+				DefaultNodeSelector: "foo=bar",
+			},
+		},
+		new: node.RuntimeClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			Handler:    "bar",
+			Topology: &node.Topology{
+				DefaultNodeSelector: "foo=baz",
+			},
+		},
+	}, {
+		name: "valid DefaultNodeSelector introduction on a RuntimeClass with no prior Topology",
+		old: node.RuntimeClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			Handler:    "bar",
+		},
+		new: node.RuntimeClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			Handler:    "bar",
+			Topology: &node.Topology{
+				DefaultNodeSelector: "foo=bar",
+			},
+		},
+	}, {
+		name:        "invalid NamespaceSelector update",
+		expectError: true,
+		old: node.RuntimeClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			Handler:    "bar",
+			Topology: &node.Topology{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+			},
+		},
+		new: node.RuntimeClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			Handler:    "bar",
+			Topology: &node.Topology{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "b"}},
+			},
+		},
+	}, {
+		name:        "invalid Requirements update",
+		expectError: true,
+		old: node.RuntimeClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			Handler:    "bar",
+			Topology: &node.Topology{
+				Requirements: []core.NodeSelectorRequirement{{
+					Key:      "pool",
+					Operator: core.NodeSelectorOpIn,
+					Values:   []string{"batch"},
+				}},
+			},
+		},
+		new: node.RuntimeClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			Handler:    "bar",
+			Topology: &node.Topology{
+				Requirements: []core.NodeSelectorRequirement{{
+					Key:      "pool",
+					Operator: core.NodeSelectorOpIn,
+					Values:   []string{"interactive"},
+				}},
+			},
+		},
 	}}
 
 	for _, test := range tests {
@@ -202,6 +327,188 @@ func TestValidateTopology(t *testing.T) {
 			}},
 		},
 		expectErrs: 2,
+	}, {
+		name: "valid namespace selector",
+		topology: &node.Topology{
+			NamespaceSelector:   &metav1.LabelSelector{MatchLabels: map[string]string{"team": "batch"}},
+			DefaultNodeSelector: "pool=batch",
+		},
+	}, {
+		name: "malformed namespace selector",
+		topology: &node.Topology{
+			NamespaceSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{
+					Key:      "team",
+					Operator: "not-an-operator",
+				}},
+			},
+		},
+		expectErrs: 1,
+	}, {
+		name: "malformed defaultNodeSelector",
+		topology: &node.Topology{
+			DefaultNodeSelector: "not a valid selector!!!",
+		},
+		expectErrs: 1,
+	}, {
+		name: "conflicting NodeSelector and DefaultNodeSelector keys",
+		topology: &node.Topology{
+			NodeSelector: &core.NodeSelector{
+				NodeSelectorTerms: []core.NodeSelectorTerm{{
+					MatchExpressions: []core.NodeSelectorRequirement{{
+						Key:      "pool",
+						Operator: core.NodeSelectorOpIn,
+						Values:   []string{"batch"},
+					}},
+				}},
+			},
+			DefaultNodeSelector: "pool=interactive",
+		},
+		expectErrs: 1,
+	}, {
+		name: "valid requirements",
+		topology: &node.Topology{
+			Requirements: []core.NodeSelectorRequirement{{
+				Key:      "pool",
+				Operator: core.NodeSelectorOpIn,
+				Values:   []string{"batch"},
+			}},
+		},
+	}, {
+		name: "requirements combined with nodeSelector",
+		topology: &node.Topology{
+			NodeSelector: &core.NodeSelector{
+				NodeSelectorTerms: []core.NodeSelectorTerm{{
+					MatchExpressions: []core.NodeSelectorRequirement{{
+						Key:      "valid",
+						Operator: core.NodeSelectorOpExists,
+					}},
+				}},
+			},
+			Requirements: []core.NodeSelectorRequirement{{
+				Key:      "pool",
+				Operator: core.NodeSelectorOpIn,
+				Values:   []string{"batch"},
+			}},
+		},
+		expectErrs: 1,
+	}, {
+		name: "requirement missing values for In",
+		topology: &node.Topology{
+			Requirements: []core.NodeSelectorRequirement{{
+				Key:      "pool",
+				Operator: core.NodeSelectorOpIn,
+			}},
+		},
+		expectErrs: 1,
+	}, {
+		name: "contradictory requirements on the same key",
+		topology: &node.Topology{
+			Requirements: []core.NodeSelectorRequirement{{
+				Key:      "pool",
+				Operator: core.NodeSelectorOpIn,
+				Values:   []string{"batch"},
+			}, {
+				Key:      "pool",
+				Operator: core.NodeSelectorOpNotIn,
+				Values:   []string{"batch"},
+			}},
+		},
+		expectErrs: 1,
+	}, {
+		name: "partially overlapping In/NotIn requirements are satisfiable",
+		topology: &node.Topology{
+			Requirements: []core.NodeSelectorRequirement{{
+				Key:      "pool",
+				Operator: core.NodeSelectorOpIn,
+				Values:   []string{"a", "b"},
+			}, {
+				Key:      "pool",
+				Operator: core.NodeSelectorOpNotIn,
+				Values:   []string{"a"},
+			}},
+		},
+	}, {
+		name: "two disjoint In requirements on the same key are contradictory",
+		topology: &node.Topology{
+			Requirements: []core.NodeSelectorRequirement{{
+				Key:      "pool",
+				Operator: core.NodeSelectorOpIn,
+				Values:   []string{"a"},
+			}, {
+				Key:      "pool",
+				Operator: core.NodeSelectorOpIn,
+				Values:   []string{"b"},
+			}},
+		},
+		expectErrs: 1,
+	}, {
+		name: "requirements conflicting with defaultNodeSelector keys",
+		topology: &node.Topology{
+			Requirements: []core.NodeSelectorRequirement{{
+				Key:      "pool",
+				Operator: core.NodeSelectorOpIn,
+				Values:   []string{"batch"},
+			}},
+			DefaultNodeSelector: "pool=interactive",
+		},
+		expectErrs: 1,
+	}, {
+		name: "duplicate tolerations",
+		topology: &node.Topology{
+			Tolerations: []core.Toleration{{
+				Key:      "valid",
+				Operator: core.TolerationOpExists,
+				Effect:   core.TaintEffectNoSchedule,
+			}, {
+				Key:      "valid",
+				Operator: core.TolerationOpExists,
+				Effect:   core.TaintEffectNoSchedule,
+			}},
+		},
+		expectErrs: 1,
+	}, {
+		name: "Exists toleration with a value",
+		topology: &node.Topology{
+			Tolerations: []core.Toleration{{
+				Key:      "valid",
+				Operator: core.TolerationOpExists,
+				Value:    "oops",
+				Effect:   core.TaintEffectNoSchedule,
+			}},
+		},
+		expectErrs: 1,
+	}, {
+		name: "TolerationSeconds without NoExecute",
+		topology: &node.Topology{
+			Tolerations: []core.Toleration{{
+				Key:               "valid",
+				Operator:          core.TolerationOpExists,
+				Effect:            core.TaintEffectNoSchedule,
+				TolerationSeconds: pointer.Int64Ptr(30),
+			}},
+		},
+		expectErrs: 1,
+	}, {
+		name:       "too many tolerations",
+		topology:   &node.Topology{Tolerations: manyValidTolerations(33)},
+		expectErrs: 1,
+	}, {
+		name: "multiple toleration violations combined",
+		topology: &node.Topology{
+			Tolerations: []core.Toleration{{
+				Key:      "valid",
+				Operator: core.TolerationOpExists,
+				Value:    "oops",
+				Effect:   core.TaintEffectNoSchedule,
+			}, {
+				Key:      "valid",
+				Operator: core.TolerationOpExists,
+				Value:    "oops",
+				Effect:   core.TaintEffectNoSchedule,
+			}},
+		},
+		expectErrs: 3, // duplicate + Exists-with-value on each of the two entries
 	}}
 
 	for _, test := range tests {
@@ -215,3 +522,17 @@ func TestValidateTopology(t *testing.T) {
 		})
 	}
 }
+
+// manyValidTolerations returns n distinct, otherwise-valid tolerations, used
+// to exercise the cap on the total number of RuntimeClass tolerations.
+func manyValidTolerations(n int) []core.Toleration {
+	tolerations := make([]core.Toleration, n)
+	for i := range tolerations {
+		tolerations[i] = core.Toleration{
+			Key:      fmt.Sprintf("valid-%d", i),
+			Operator: core.TolerationOpExists,
+			Effect:   core.TaintEffectNoSchedule,
+		}
+	}
+	return tolerations
+}